@@ -36,7 +36,7 @@ func Handled(err error) error {
 	if err == nil {
 		return nil
 	}
-	return HandledWithMessage(err, err.Error())
+	return newSanitizedBarrier(1, err, err.Error())
 }
 
 // HandledWithMessage is like Handled except the message is overridden.
@@ -46,7 +46,7 @@ func HandledWithMessage(err error, msg string) error {
 	if err == nil {
 		return nil
 	}
-	return &barrierError{maskedErr: err, msg: msg}
+	return newBarrier(1, err, msg, nil)
 }
 
 // HandledWithMessagef is like HandledWithMessagef except the message
@@ -55,7 +55,34 @@ func HandledWithMessagef(err error, format string, args ...interface{}) error {
 	if err == nil {
 		return nil
 	}
-	return &barrierError{maskedErr: err, msg: fmt.Sprintf(format, args...)}
+	return newSanitizedBarrier(1, err, fmt.Sprintf(format, args...))
+}
+
+// HandledAt is like Handled, except the barrier is attributed to the
+// call site skip frames above its own caller instead of to that
+// caller. This is meant for wrapper libraries that construct a
+// barrier on a caller's behalf and want the recorded frame to point
+// at that caller rather than at themselves.
+func HandledAt(err error, skip int) error {
+	if err == nil {
+		return nil
+	}
+	return newSanitizedBarrier(skip+1, err, err.Error())
+}
+
+// newSanitizedBarrier is like newBarrier, except msg is first run
+// through the registered MessageSanitizer: the visible message becomes
+// the sanitized one, and whatever it redacted is preserved as
+// additional SafeDetails rather than silently discarded.
+func newSanitizedBarrier(skip int, err error, msg string) error {
+	safe, redactions := sanitizer.Sanitize(msg)
+	return newBarrier(skip+1, err, safe, redactions)
+}
+
+// newBarrier constructs a barrierError, capturing the frame skip
+// levels above its own caller.
+func newBarrier(skip int, err error, msg string, extraDetails []string) error {
+	return &barrierError{maskedErr: err, msg: msg, frame: captureFrame(skip + 1), extraDetails: extraDetails}
 }
 
 // barrierError is a leaf error type. It encapsulates a chain of
@@ -71,6 +98,22 @@ type barrierError struct {
 	msg string
 	// Masked error chain.
 	maskedErr error
+	// frame is the call site where the barrier was constructed, i.e.
+	// where the original error was swallowed. It is noFrame if it
+	// could not be captured. It is only surfaced via Printer.Detail(),
+	// mirroring the convention used by Go's errors.New.
+	//
+	// frame is local-only: it holds raw program counters that are only
+	// meaningful inside the process that captured them, so, like the
+	// frames captured by the library's withstack package, it is never
+	// encoded into the wire payload and does not survive DecodeError.
+	frame errbase.StackTrace
+	// extraDetails holds PII-free descriptions of whatever the
+	// registered MessageSanitizer stripped out of msg, e.g. "redacted
+	// ip matching ...". They are reported alongside the masked error's
+	// own SafeDetails so debuggers retain a clue even though Error()
+	// itself is now safe to surface.
+	extraDetails []string
 }
 
 var _ error = (*barrierError)(nil)
@@ -81,9 +124,11 @@ var _ fmt.Formatter = (*barrierError)(nil)
 // barrierError is an error.
 func (e *barrierError) Error() string { return e.msg }
 
-// SafeDetails reports the PII-free details from the masked error.
+// SafeDetails reports the PII-free details from the masked error, plus
+// whatever the MessageSanitizer redacted from the barrier's own
+// message.
 func (e *barrierError) SafeDetails() []string {
-	var details []string
+	details := append([]string(nil), e.extraDetails...)
 	for err := e.maskedErr; err != nil; err = errbase.UnwrapOnce(err) {
 		sd := errbase.GetSafeDetails(err)
 		details = sd.Fill(details)
@@ -97,12 +142,17 @@ func (e *barrierError) Format(s fmt.State, verb rune) { errbase.FormatError(e, s
 func (e *barrierError) FormatError(p errbase.Printer) (next error) {
 	p.Print(e.msg)
 	if p.Detail() {
+		if len(e.frame) > 0 {
+			p.Printf("\nbarrier constructed at:\n%+v", e.frame)
+		}
 		p.Printf("\noriginal cause behind barrier:\n%+v", e.maskedErr)
 	}
 	return nil
 }
 
-// A barrier error is encoded exactly.
+// A barrier error is encoded exactly. The frame is not part of the
+// payload: it is a local-only artifact (see the frame field comment
+// above) that does not survive a decode on the receiving end.
 func encodeBarrier(
 	ctx context.Context, err error,
 ) (msg string, details []string, payload proto.Message) {
@@ -111,7 +161,10 @@ func encodeBarrier(
 	return e.msg, e.SafeDetails(), &enc
 }
 
-// A barrier error is decoded exactly.
+// A barrier error is decoded from its message and its encoded masked
+// cause. The decoded error has no frame (see the frame field comment
+// above) and no extraDetails: the MessageSanitizer's redaction notes
+// are not restored into its SafeDetails.
 func decodeBarrier(ctx context.Context, msg string, _ []string, payload proto.Message) error {
 	enc := payload.(*errbase.EncodedError)
 	return &barrierError{msg: msg, maskedErr: errbase.DecodeError(ctx, *enc)}
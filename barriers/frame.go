@@ -0,0 +1,51 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import (
+	"runtime"
+
+	"github.com/cockroachdb/errors/errbase"
+	pkgErrors "github.com/pkg/errors"
+)
+
+// noFrame is the zero value of errbase.StackTrace, used for barriers
+// that, for whatever reason (e.g. HandledAt called with an
+// out-of-range skip), were unable to capture their construction site.
+// errbase.StackTrace is a slice, so it is only ever compared via
+// len(), never with ==.
+var noFrame errbase.StackTrace
+
+// captureFrame records the call site located skip frames above its
+// own caller, following the convention used by Go's errors.New (which
+// records a runtime.Frame via Caller(1)). skip == 0 designates the
+// caller of captureFrame itself.
+//
+// The returned stack trace is only ever consulted locally, within the
+// same process that captured it: errbase.StackTrace is a
+// github.com/pkg/errors stack trace, i.e. a slice of raw program
+// counters. Those program counters are meaningless once decoded in a
+// different process or binary (they'd symbolize to garbage or an
+// unrelated function), so, like the library's withstack package, we
+// never register an encoder/decoder for it and it never crosses the
+// network.
+func captureFrame(skip int) errbase.StackTrace {
+	var pc [1]uintptr
+	n := runtime.Callers(skip+2, pc[:])
+	if n == 0 {
+		return noFrame
+	}
+	return errbase.StackTrace{pkgErrors.Frame(pc[0])}
+}
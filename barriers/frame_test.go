@@ -0,0 +1,48 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHandledCapturesFrame(t *testing.T) {
+	barrier := Handled(errors.New("boom"))
+	detail := fmt.Sprintf("%+v", barrier)
+	if !strings.Contains(detail, "barrier constructed at:") {
+		t.Fatalf("expected a captured frame in detail output, got:\n%s", detail)
+	}
+	if !strings.Contains(detail, "TestHandledCapturesFrame") {
+		t.Fatalf("expected the frame to point at this test, got:\n%s", detail)
+	}
+}
+
+func wrapWithHandledAt(err error) error {
+	return HandledAt(err, 1)
+}
+
+func TestHandledAtAttributesToCaller(t *testing.T) {
+	barrier := wrapWithHandledAt(errors.New("boom"))
+	detail := fmt.Sprintf("%+v", barrier)
+	if strings.Contains(detail, "wrapWithHandledAt") {
+		t.Fatalf("expected HandledAt to skip its wrapper's frame, got:\n%s", detail)
+	}
+	if !strings.Contains(detail, "TestHandledAtAttributesToCaller") {
+		t.Fatalf("expected the frame to point at the wrapper's caller, got:\n%s", detail)
+	}
+}
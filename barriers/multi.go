@@ -0,0 +1,124 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/gogo/protobuf/proto"
+)
+
+// HandledMany is like Handled, except it masks a set of causes behind
+// a single barrier instead of just one. This is meant for distributed
+// fan-out: a coordinator gathers N worker errors, wants to present one
+// sanitized message upstream, but needs every original cause retained
+// for Sentry/logs. nil errors in errs are skipped. HandledMany returns
+// nil if errs contains no non-nil error.
+func HandledMany(errs ...error) error {
+	var causes []error
+	for _, err := range errs {
+		if err != nil {
+			causes = append(causes, err)
+		}
+	}
+	if len(causes) == 0 {
+		return nil
+	}
+	return &multiBarrierError{
+		msg:        fmt.Sprintf("%d errors occurred", len(causes)),
+		maskedErrs: causes,
+		frame:      captureFrame(1),
+	}
+}
+
+// multiBarrierError is a leaf error type like barrierError, except it
+// masks a set of causes instead of a single one.
+type multiBarrierError struct {
+	msg        string
+	maskedErrs []error
+	// frame is local-only; see the field comment on barrierError.frame
+	// in barriers.go for why it is never part of the wire payload.
+	frame errbase.StackTrace
+}
+
+var _ error = (*multiBarrierError)(nil)
+var _ errbase.SafeDetailer = (*multiBarrierError)(nil)
+var _ errbase.Formatter = (*multiBarrierError)(nil)
+var _ fmt.Formatter = (*multiBarrierError)(nil)
+
+func (e *multiBarrierError) Error() string { return e.msg }
+
+// SafeDetails reports the PII-free details from every masked cause,
+// each one prefixed by its index.
+func (e *multiBarrierError) SafeDetails() []string {
+	var details []string
+	for i, cause := range e.maskedErrs {
+		for err := cause; err != nil; err = errbase.UnwrapOnce(err) {
+			sd := errbase.GetSafeDetails(err)
+			for _, d := range sd.Fill(nil) {
+				details = append(details, fmt.Sprintf("[%d] %s", i, d))
+			}
+		}
+	}
+	return details
+}
+
+func (e *multiBarrierError) Format(s fmt.State, verb rune) { errbase.FormatError(e, s, verb) }
+
+func (e *multiBarrierError) FormatError(p errbase.Printer) (next error) {
+	p.Print(e.msg)
+	if p.Detail() {
+		if len(e.frame) > 0 {
+			p.Printf("\nbarrier constructed at:\n%+v", e.frame)
+		}
+		for i, cause := range e.maskedErrs {
+			p.Printf("\nmasked cause [%d]: %+v", i, cause)
+		}
+	}
+	return nil
+}
+
+// A multi-barrier error is encoded exactly: every masked cause is
+// encoded in turn. The frame is not part of the payload; see the
+// field comment on multiBarrierError.frame.
+func encodeMultiBarrier(
+	ctx context.Context, err error,
+) (msg string, details []string, payload proto.Message) {
+	e := err.(*multiBarrierError)
+	encs := make([]errbase.EncodedError, len(e.maskedErrs))
+	for i, cause := range e.maskedErrs {
+		encs[i] = errbase.EncodeError(ctx, cause)
+	}
+	return e.msg, e.SafeDetails(), &EncodedMultiBarrier{Causes: encs}
+}
+
+// A multi-barrier error is decoded exactly. The decoded error has no
+// frame, for the same reason a decoded barrierError has none.
+func decodeMultiBarrier(ctx context.Context, msg string, _ []string, payload proto.Message) error {
+	enc := payload.(*EncodedMultiBarrier)
+	causes := make([]error, len(enc.Causes))
+	for i, c := range enc.Causes {
+		causes[i] = errbase.DecodeError(ctx, c)
+	}
+	return &multiBarrierError{msg: msg, maskedErrs: causes}
+}
+
+func init() {
+	tn := errbase.GetTypeKey((*multiBarrierError)(nil))
+	errbase.RegisterLeafDecoder(tn, decodeMultiBarrier)
+	errbase.RegisterLeafEncoder(tn, encodeMultiBarrier)
+}
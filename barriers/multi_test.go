@@ -0,0 +1,86 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+func TestHandledManyAllNilIsNil(t *testing.T) {
+	if got := HandledMany(nil, nil); got != nil {
+		t.Fatalf("HandledMany(nil, nil) = %v, want nil", got)
+	}
+	if got := HandledMany(); got != nil {
+		t.Fatalf("HandledMany() = %v, want nil", got)
+	}
+}
+
+func TestHandledManySkipsNilAndHidesCauses(t *testing.T) {
+	barrier := HandledMany(errors.New("err1"), nil, errors.New("err2"))
+
+	if got := errbase.UnwrapOnce(barrier); got != nil {
+		t.Fatalf("expected Unwrap to be hidden, got %v", got)
+	}
+
+	detail := fmt.Sprintf("%+v", barrier)
+	if !strings.Contains(detail, "masked cause [0]: err1") {
+		t.Fatalf("expected cause [0] in detail output, got:\n%s", detail)
+	}
+	if !strings.Contains(detail, "masked cause [1]: err2") {
+		t.Fatalf("expected cause [1] in detail output, got:\n%s", detail)
+	}
+}
+
+func TestHandledManySafeDetailsAreIndexed(t *testing.T) {
+	// Each cause is a sanitized barrier, so it reports safe details of
+	// its own (the sanitizer's redaction notes) for the multi-barrier
+	// to prefix with the cause's index.
+	barrier := HandledMany(Handled(errors.New("err1 10.0.0.1")), Handled(errors.New("err2 10.0.0.2")))
+	sd, ok := barrier.(errbase.SafeDetailer)
+	if !ok {
+		t.Fatalf("expected a SafeDetailer, got %T", barrier)
+	}
+	details := sd.SafeDetails()
+	if len(details) == 0 {
+		t.Fatalf("expected safe details from the masked causes, got none")
+	}
+	for _, d := range details {
+		if !strings.HasPrefix(d, "[0] ") && !strings.HasPrefix(d, "[1] ") {
+			t.Fatalf("expected each detail to be prefixed by its index, got %q", d)
+		}
+	}
+}
+
+func TestHandledManyEncodeDecodeRoundTrip(t *testing.T) {
+	barrier := HandledMany(errors.New("err1"), errors.New("err2"))
+	ctx := context.Background()
+
+	enc := errbase.EncodeError(ctx, barrier)
+	decoded := errbase.DecodeError(ctx, enc)
+
+	if decoded.Error() != barrier.Error() {
+		t.Fatalf("decoded.Error() = %q, want %q", decoded.Error(), barrier.Error())
+	}
+	detail := fmt.Sprintf("%+v", decoded)
+	if !strings.Contains(detail, "masked cause [0]: err1") || !strings.Contains(detail, "masked cause [1]: err2") {
+		t.Fatalf("decoded barrier lost its masked causes, got:\n%s", detail)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/gogo/protobuf/proto"
+)
+
+// RedactionKey is a symmetric key used to encrypt (and later decrypt)
+// the cause masked by HandledWithRedaction. It must be exactly 32
+// bytes long (AES-256); HandledWithRedaction rejects any other length.
+type RedactionKey []byte
+
+// redactionKeySize is the only accepted RedactionKey length: AES-256.
+const redactionKeySize = 32
+
+// redactionKeyCtxKey is the context key under which redaction keys
+// are attached by WithRedactionKeys.
+type redactionKeyCtxKey struct{}
+
+// WithRedactionKeys attaches a set of redaction keys to ctx. DecodeError
+// uses these keys, if present, to transparently unwrap errors produced
+// by HandledWithRedaction on the sending side.
+func WithRedactionKeys(ctx context.Context, keys ...RedactionKey) context.Context {
+	return context.WithValue(ctx, redactionKeyCtxKey{}, keys)
+}
+
+func redactionKeysFromCtx(ctx context.Context) []RedactionKey {
+	keys, _ := ctx.Value(redactionKeyCtxKey{}).([]RedactionKey)
+	return keys
+}
+
+// HandledWithRedaction is like Handled, except the masked cause is not
+// retained in the clear: it is symmetrically encrypted with key so that
+// it can travel over the network (e.g. inside a barrier error reported
+// to Sentry, or forwarded between nodes) without ever exposing the
+// original error text or its PII-unsafe details to any intermediate
+// hop. Only a caller that possesses key, via Reveal, can recover the
+// original cause.
+//
+// key must be exactly 32 bytes (AES-256); any other length is rejected
+// up front with an error rather than silently falling back to a
+// weaker cipher or failing deep inside the encryption path. If
+// encryption itself fails, HandledWithRedaction also returns an error
+// instead of masking the failure behind an opaque barrier: silently
+// discarding err in that case would mean a single bad key loses every
+// masked cause for good, with no way for the caller to notice or
+// recover.
+func HandledWithRedaction(err error, key RedactionKey) (error, error) {
+	if err == nil {
+		return nil, nil
+	}
+	if len(key) != redactionKeySize {
+		return nil, fmt.Errorf("barriers: redaction key must be %d bytes (AES-256), got %d", redactionKeySize, len(key))
+	}
+	ciphertext, err2 := encryptCause(err, key)
+	if err2 != nil {
+		return nil, fmt.Errorf("barriers: encrypting redacted cause: %w", err2)
+	}
+	// The visible message is always the placeholder, never err.Error():
+	// the whole point of this constructor is that the original error
+	// text must not reach intermediate hops, Sentry, or a keyless
+	// receiver in the clear.
+	return &redactedBarrierError{msg: redactedPlaceholder(len(ciphertext)), ciphertext: ciphertext}, nil
+}
+
+// Reveal decrypts the cause masked by a HandledWithRedaction error
+// using key, returning the original error. It returns nil if err is
+// not a redacted barrier, and an error if key does not match the one
+// used at construction time.
+func Reveal(err error, key RedactionKey) (error, error) {
+	e, ok := err.(*redactedBarrierError)
+	if !ok {
+		return nil, nil
+	}
+	return decryptCause(e.ciphertext, key)
+}
+
+// redactedBarrierError is a leaf error type like barrierError, except
+// the masked cause is stored as ciphertext rather than in the clear.
+type redactedBarrierError struct {
+	msg        string
+	ciphertext []byte
+}
+
+var _ error = (*redactedBarrierError)(nil)
+var _ errbase.SafeDetailer = (*redactedBarrierError)(nil)
+var _ errbase.Formatter = (*redactedBarrierError)(nil)
+var _ fmt.Formatter = (*redactedBarrierError)(nil)
+
+func (e *redactedBarrierError) Error() string { return e.msg }
+
+// SafeDetails reports a PII-free placeholder: the cause is encrypted
+// and cannot be inspected without the redaction key.
+func (e *redactedBarrierError) SafeDetails() []string {
+	return []string{redactedPlaceholder(len(e.ciphertext))}
+}
+
+func (e *redactedBarrierError) Format(s fmt.State, verb rune) { errbase.FormatError(e, s, verb) }
+
+func (e *redactedBarrierError) FormatError(p errbase.Printer) (next error) {
+	// e.msg is already the placeholder (see HandledWithRedaction): there
+	// is nothing more to reveal here without the key, so Detail() has
+	// nothing additional to print.
+	p.Print(e.msg)
+	return nil
+}
+
+func redactedPlaceholder(ciphertextLen int) string {
+	return fmt.Sprintf("<redacted cause: %d bytes>", ciphertextLen)
+}
+
+// encodeRedactedBarrier encodes a redactedBarrierError. The ciphertext
+// is carried as-is in the protobuf payload: it is already opaque, so
+// it can cross the network without further protection.
+func encodeRedactedBarrier(
+	_ context.Context, err error,
+) (msg string, details []string, payload proto.Message) {
+	e := err.(*redactedBarrierError)
+	return e.msg, e.SafeDetails(), &EncodedRedactedCause{Ciphertext: e.ciphertext}
+}
+
+// decodeRedactedBarrier decodes a redactedBarrierError. If the
+// decoding context carries a matching redaction key (see
+// WithRedactionKeys), the cause is transparently revealed and a
+// regular barrierError is returned instead, so that callers holding
+// the key don't need to call Reveal explicitly.
+func decodeRedactedBarrier(ctx context.Context, msg string, _ []string, payload proto.Message) error {
+	enc := payload.(*EncodedRedactedCause)
+	for _, key := range redactionKeysFromCtx(ctx) {
+		if cause, err := decryptCause(enc.Ciphertext, key); err == nil {
+			return &barrierError{msg: msg, maskedErr: cause}
+		}
+	}
+	return &redactedBarrierError{msg: msg, ciphertext: enc.Ciphertext}
+}
+
+func init() {
+	tn := errbase.GetTypeKey((*redactedBarrierError)(nil))
+	errbase.RegisterLeafDecoder(tn, decodeRedactedBarrier)
+	errbase.RegisterLeafEncoder(tn, encodeRedactedBarrier)
+}
+
+// encryptCause serializes err via errbase.EncodeError and seals the
+// result with AES-GCM under key.
+func encryptCause(err error, key RedactionKey) ([]byte, error) {
+	enc := errbase.EncodeError(context.Background(), err)
+	plaintext, marshalErr := proto.Marshal(&enc)
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+
+	block, blockErr := aes.NewCipher(key)
+	if blockErr != nil {
+		return nil, blockErr
+	}
+	gcm, gcmErr := cipher.NewGCM(block)
+	if gcmErr != nil {
+		return nil, gcmErr
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, randErr := io.ReadFull(rand.Reader, nonce); randErr != nil {
+		return nil, randErr
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// decryptCause reverses encryptCause.
+func decryptCause(ciphertext []byte, key RedactionKey) (error, error) {
+	block, blockErr := aes.NewCipher(key)
+	if blockErr != nil {
+		return nil, blockErr
+	}
+	gcm, gcmErr := cipher.NewGCM(block)
+	if gcmErr != nil {
+		return nil, gcmErr
+	}
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, fmt.Errorf("barriers: redacted cause too short")
+	}
+	nonce, sealed := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	plaintext, openErr := gcm.Open(nil, nonce, sealed, nil)
+	if openErr != nil {
+		return nil, openErr
+	}
+	var enc errbase.EncodedError
+	if unmarshalErr := proto.Unmarshal(plaintext, &enc); unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+	return errbase.DecodeError(context.Background(), enc), nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) RedactionKey {
+	return RedactionKey(bytes.Repeat([]byte{b}, redactionKeySize))
+}
+
+func TestHandledWithRedactionRoundTrip(t *testing.T) {
+	key := testKey(0x42)
+	cause := errors.New("boom: leaked 10.0.0.1")
+
+	barrier, err := HandledWithRedaction(cause, key)
+	if err != nil {
+		t.Fatalf("HandledWithRedaction: %v", err)
+	}
+	if strings.Contains(barrier.Error(), "10.0.0.1") {
+		t.Fatalf("barrier message leaked the cause: %q", barrier.Error())
+	}
+
+	revealed, err := Reveal(barrier, key)
+	if err != nil {
+		t.Fatalf("Reveal: %v", err)
+	}
+	if revealed.Error() != cause.Error() {
+		t.Fatalf("got %q, want %q", revealed.Error(), cause.Error())
+	}
+}
+
+func TestRevealWithWrongKeyFails(t *testing.T) {
+	barrier, err := HandledWithRedaction(errors.New("boom"), testKey(0x42))
+	if err != nil {
+		t.Fatalf("HandledWithRedaction: %v", err)
+	}
+	if _, err := Reveal(barrier, testKey(0x24)); err == nil {
+		t.Fatalf("Reveal with the wrong key unexpectedly succeeded")
+	}
+}
+
+func TestHandledWithRedactionRejectsBadKeySize(t *testing.T) {
+	for _, size := range []int{0, 16, 24, 31, 33} {
+		if _, err := HandledWithRedaction(errors.New("boom"), RedactionKey(make([]byte, size))); err == nil {
+			t.Errorf("expected an error for a %d-byte key", size)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// MessageSanitizer is a pluggable policy for scrubbing PII out of the
+// message given to Handled/HandledWithMessagef before it
+// becomes the barrier's visible Error() string. Sanitize returns the
+// safe message to display, plus the list of redactions it made (each
+// one a PII-free description of what was removed, suitable for
+// SafeDetails).
+type MessageSanitizer interface {
+	Sanitize(msg string) (safe string, redactions []string)
+}
+
+// sanitizer is the currently registered MessageSanitizer. It defaults
+// to defaultSanitizer so that Handled/HandledWithMessagef are safe to
+// use out of the box. HandledWithMessage stores its explicit message
+// as given, without sanitizing it.
+var sanitizer MessageSanitizer = defaultSanitizer{}
+
+// RegisterSanitizer overrides the package-level MessageSanitizer run
+// by Handled and HandledWithMessagef. It is meant to be called once,
+// e.g. from an init() function, before any barrier is constructed.
+func RegisterSanitizer(s MessageSanitizer) {
+	sanitizer = s
+}
+
+// defaultSanitizer strips common PII patterns: email addresses, IP
+// addresses, UUIDs, and paths under /home or /Users.
+type defaultSanitizer struct{}
+
+var (
+	emailPattern = regexp.MustCompile(`[[:alnum:].+-]+@[[:alnum:].-]+\.[[:alpha:]]{2,}`)
+	ipPattern    = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}\b`)
+	uuidPattern  = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	homePattern  = regexp.MustCompile(`/(?:home|Users)/[^\s:]+`)
+
+	defaultPatterns = []struct {
+		name string
+		re   *regexp.Regexp
+	}{
+		{"email", emailPattern},
+		{"ip", ipPattern},
+		{"uuid", uuidPattern},
+		{"home path", homePattern},
+	}
+)
+
+// Sanitize implements MessageSanitizer.
+func (defaultSanitizer) Sanitize(msg string) (safe string, redactions []string) {
+	safe = msg
+	for _, p := range defaultPatterns {
+		safe = p.re.ReplaceAllStringFunc(safe, func(match string) string {
+			redactions = append(redactions, fmt.Sprintf("redacted %s matching %s", p.name, p.re.String()))
+			return "<redacted>"
+		})
+	}
+	return safe, redactions
+}
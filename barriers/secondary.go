@@ -0,0 +1,105 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/gogo/protobuf/proto"
+)
+
+// HandledAsSecondary is like Handled: it hides err from Unwrap()/Is()
+// traversal in exactly the same way, including running its default
+// message through the registered MessageSanitizer. The only difference
+// is that the masked cause can still be recovered with Secondary, for
+// callers that know they are dealing with a barrier produced by this
+// specific constructor and want to inspect what it swallowed.
+//
+// Note that this is a barriers-package-local accessor, not an
+// integration with this library's separate "secondary error" feature
+// (the one built on errors.WithSecondaryError): generic tooling such
+// as errors.GetAllSafeDetails does not know about Secondary, and never
+// will just by virtue of this function existing. It only walks
+// SafeDetails()/Unwrap(), both of which already see the masked cause's
+// safe details exactly like Handled does, via barrierError.SafeDetails.
+func HandledAsSecondary(err error) error {
+	if err == nil {
+		return nil
+	}
+	safe, redactions := sanitizer.Sanitize(err.Error())
+	return &secondaryBarrierError{barrierError: barrierError{
+		maskedErr:    err,
+		msg:          safe,
+		frame:        captureFrame(1),
+		extraDetails: redactions,
+	}}
+}
+
+// Secondary returns the cause masked by a HandledAsSecondary error, or
+// nil if err was not produced by HandledAsSecondary.
+func Secondary(err error) error {
+	if e, ok := err.(*secondaryBarrierError); ok {
+		return e.maskedErr
+	}
+	return nil
+}
+
+// secondaryBarrierError is a barrierError that can additionally be
+// recognized and unmasked by Secondary.
+type secondaryBarrierError struct {
+	barrierError
+}
+
+var _ error = (*secondaryBarrierError)(nil)
+var _ errbase.SafeDetailer = (*secondaryBarrierError)(nil)
+var _ errbase.Formatter = (*secondaryBarrierError)(nil)
+var _ fmt.Formatter = (*secondaryBarrierError)(nil)
+
+func (e *secondaryBarrierError) Format(s fmt.State, verb rune) { errbase.FormatError(e, s, verb) }
+
+func (e *secondaryBarrierError) FormatError(p errbase.Printer) (next error) {
+	p.Print(e.msg)
+	if p.Detail() {
+		if len(e.frame) > 0 {
+			p.Printf("\nbarrier constructed at:\n%+v", e.frame)
+		}
+		p.Printf("\nsecondary cause behind barrier:\n%+v", e.maskedErr)
+	}
+	return nil
+}
+
+// The secondary barrier is encoded/decoded exactly like barrierError;
+// see encodeBarrier/decodeBarrier for why the frame is not part of the
+// payload.
+func encodeSecondaryBarrier(
+	ctx context.Context, err error,
+) (msg string, details []string, payload proto.Message) {
+	e := err.(*secondaryBarrierError)
+	enc := errbase.EncodeError(ctx, e.maskedErr)
+	return e.msg, e.SafeDetails(), &enc
+}
+
+func decodeSecondaryBarrier(ctx context.Context, msg string, _ []string, payload proto.Message) error {
+	enc := payload.(*errbase.EncodedError)
+	return &secondaryBarrierError{barrierError: barrierError{msg: msg, maskedErr: errbase.DecodeError(ctx, *enc)}}
+}
+
+func init() {
+	tn := errbase.GetTypeKey((*secondaryBarrierError)(nil))
+	errbase.RegisterLeafDecoder(tn, decodeSecondaryBarrier)
+	errbase.RegisterLeafEncoder(tn, encodeSecondaryBarrier)
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package barriers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+func TestHandledAsSecondaryHidesCauseFromUnwrap(t *testing.T) {
+	cause := errors.New("boom")
+	barrier := HandledAsSecondary(cause)
+
+	if got := errbase.UnwrapOnce(barrier); got != nil {
+		t.Fatalf("expected Unwrap to be hidden, got %v", got)
+	}
+	if got := Secondary(barrier); got != cause {
+		t.Fatalf("Secondary() = %v, want %v", got, cause)
+	}
+}
+
+func TestSecondaryOnNonSecondaryBarrierIsNil(t *testing.T) {
+	if got := Secondary(Handled(errors.New("boom"))); got != nil {
+		t.Fatalf("Secondary() on a plain barrier = %v, want nil", got)
+	}
+	if got := Secondary(errors.New("boom")); got != nil {
+		t.Fatalf("Secondary() on a non-barrier = %v, want nil", got)
+	}
+}
+
+func TestHandledAsSecondarySanitizesMessage(t *testing.T) {
+	barrier := HandledAsSecondary(errors.New("boom 10.1.1.1"))
+	if barrier.Error() == "boom 10.1.1.1" {
+		t.Fatalf("barrier message was not sanitized: %q", barrier.Error())
+	}
+}
+
+func TestHandledAsSecondaryEncodeDecodeRoundTrip(t *testing.T) {
+	barrier := HandledAsSecondary(errors.New("boom"))
+	ctx := context.Background()
+
+	enc := errbase.EncodeError(ctx, barrier)
+	decoded := errbase.DecodeError(ctx, enc)
+
+	if decoded.Error() != barrier.Error() {
+		t.Fatalf("decoded.Error() = %q, want %q", decoded.Error(), barrier.Error())
+	}
+	if got := Secondary(decoded); got == nil || got.Error() != "boom" {
+		t.Fatalf("Secondary(decoded) = %v, want an error reading \"boom\"", got)
+	}
+}